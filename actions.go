@@ -1,26 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
 	groth16 "github.com/consensys/gnark/backend/groth16/bn254"
 	"github.com/consensys/gnark/backend/groth16/bn254/mpcsetup"
 	"github.com/consensys/gnark/backend/solidity"
 	cs "github.com/consensys/gnark/constraint/bn254"
 	"github.com/urfave/cli/v2"
 	deserializer "github.com/worldcoin/ptau-deserializer/deserialize"
+	"golang.org/x/crypto/sha3"
 )
 
 const Region = "us-east-2"
@@ -75,6 +73,18 @@ func p2n(cCtx *cli.Context) error {
 	phase1 := &mpcsetup.Phase1{}
 	phase1.ReadFrom(phase1File)
 
+	if beaconRound := cCtx.Uint64("beacon-round"); beaconRound > 0 {
+		fmt.Printf("Finalizing phase 1 with drand round %d\n", beaconRound)
+		beacon, record, err := fetchBeacon(cCtx, "phase1", beaconRound)
+		if err != nil {
+			return fmt.Errorf("phase 1 beacon: %w", err)
+		}
+		withBeaconRandomness(beacon, phase1.Contribute)
+		if err := writeBeaconRecords("beacon.json", []BeaconRecord{*record}); err != nil {
+			return err
+		}
+	}
+
 	r1csFile, err := os.Open(r1csPath)
 	if err != nil {
 		return err
@@ -101,11 +111,12 @@ func p2n(cCtx *cli.Context) error {
 
 func p2c(cCtx *cli.Context) error {
 	var err error
-	if cCtx.Args().Len() != 1 {
+	if cCtx.Args().Len() != 2 {
 		return errors.New("please provide the correct arguments")
 	}
 
 	presignedUploadUrl := cCtx.Args().Get(0)
+	presignedAttestationUploadUrl := cCtx.Args().Get(1)
 	re := regexp.MustCompile(`phase2(?:-(?<index>\d+))?\?`)
 	matches := re.FindStringSubmatch(presignedUploadUrl)
 	contributionIndex := 0
@@ -129,13 +140,13 @@ func p2c(cCtx *cli.Context) error {
 
 	outputPh2Path := fmt.Sprintf("./trusted-setup/phase2-%d", contributionIndex)
 
-	svc, err := GetS3Service(Region, true)
+	store, err := storageFromContext(cCtx)
 	if err != nil {
 		return err
 	}
 
 	fmt.Printf("Downloading previous contribution: %s\n", previousContributionObjectKey)
-	inputPh2Path, err := Download(svc, previousContributionObjectKey)
+	inputPh2Path, err := Download(store, previousContributionObjectKey)
 	if err != nil {
 		return err
 	}
@@ -146,9 +157,21 @@ func p2c(cCtx *cli.Context) error {
 	}
 	phase2 := &mpcsetup.Phase2{}
 	phase2.ReadFrom(inputFile)
+	prevHash := hex.EncodeToString(phase2.Hash)
 
-	fmt.Printf("Generating contribution\n")
-	phase2.Contribute()
+	var beaconRound *uint64
+	if round := cCtx.Uint64("beacon-round"); round > 0 {
+		fmt.Printf("Generating contribution finalized with drand round %d\n", round)
+		beacon, _, err := fetchBeacon(cCtx, "phase2", round)
+		if err != nil {
+			return fmt.Errorf("contribution beacon: %w", err)
+		}
+		withBeaconRandomness(beacon, phase2.Contribute)
+		beaconRound = &round
+	} else {
+		fmt.Printf("Generating contribution\n")
+		phase2.Contribute()
+	}
 
 	outputFile, err := os.Create(outputPh2Path)
 	if err != nil {
@@ -162,7 +185,35 @@ func p2c(cCtx *cli.Context) error {
 		return err
 	}
 
-	return nil
+	att := Attestation{
+		Index:       contributionIndex,
+		Name:        cCtx.String("name"),
+		Email:       cCtx.String("email"),
+		GitHub:      cCtx.String("github"),
+		PrevHash:    prevHash,
+		NewHash:     hex.EncodeToString(phase2.Hash),
+		BeaconRound: beaconRound,
+		Timestamp:   time.Now().UTC(),
+	}
+	if err := signAttestation(&att, cCtx.String("sign-key")); err != nil {
+		return err
+	}
+
+	attestationPath := fmt.Sprintf("./trusted-setup/phase2-%d.attestation.json", contributionIndex)
+	attestationFile, err := os.Create(attestationPath)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(attestationFile)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(att)
+	attestationFile.Close()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Uploading attestation: phase2-%d.attestation.json\n", contributionIndex)
+	return Upload(attestationPath, presignedAttestationUploadUrl)
 }
 
 func p2v(cCtx *cli.Context) error {
@@ -171,7 +222,7 @@ func p2v(cCtx *cli.Context) error {
 	}
 	contributionIndex := cCtx.Args().Get(0)
 
-	svc, err := GetS3Service(Region, true)
+	store, err := storageFromContext(cCtx)
 	if err != nil {
 		return err
 	}
@@ -179,7 +230,7 @@ func p2v(cCtx *cli.Context) error {
 	currentContribution := fmt.Sprintf("phase2-%s", contributionIndex)
 
 	fmt.Printf("Downloading current contribution: %s\n", currentContribution)
-	inputPath, err := Download(svc, currentContribution)
+	inputPath, err := Download(store, currentContribution)
 	if err != nil {
 		return err
 	}
@@ -192,7 +243,7 @@ func p2v(cCtx *cli.Context) error {
 	input.ReadFrom(inputFile)
 
 	fmt.Printf("Downloading phase2\n")
-	originPath, err := Download(svc, "phase2")
+	originPath, err := Download(store, "phase2")
 	if err != nil {
 		return err
 	}
@@ -212,8 +263,7 @@ func p2v(cCtx *cli.Context) error {
 }
 
 func p2u(cCtx *cli.Context) error {
-
-	svc, err := GetS3Service(Region, false)
+	store, err := storageFromContext(cCtx)
 	if err != nil {
 		return err
 	}
@@ -225,27 +275,12 @@ func p2u(cCtx *cli.Context) error {
 	}
 	defer file.Close()
 
-	// Get file size and read the file content
 	fileInfo, err := file.Stat()
 	if err != nil {
 		return err
 	}
 
-	// Create an S3 upload input parameters
-	uploadInput := &s3.PutObjectInput{
-		Bucket:        aws.String(BucketName),
-		Key:           aws.String(filepath.Base("phase2")),
-		Body:          file,
-		ContentLength: aws.Int64(fileInfo.Size()),
-	}
-
-	// Upload the file
-	_, err = svc.PutObject(uploadInput)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return store.Put("phase2", file, fileInfo.Size())
 }
 
 func presigned(cCtx *cli.Context) error {
@@ -262,29 +297,23 @@ func presigned(cCtx *cli.Context) error {
 
 	putLifetime := 7 * 24 * time.Hour
 
-	svc, err := GetS3Service(Region, false)
+	store, err := storageFromContext(cCtx)
 	if err != nil {
 		return err
 	}
 
 	for i := 0; i < count; i++ {
-		// Create the PutObjectInput parameters
-		putObjectInput := &s3.PutObjectInput{
-			Bucket: aws.String(BucketName),
-			Key:    aws.String(fmt.Sprintf("phase2-%d", i)),
+		putURLStr, err := store.PresignPut(fmt.Sprintf("phase2-%d", i), putLifetime)
+		if err != nil {
+			return err
 		}
-
-		// Create a request object for PutObject
-		req, _ := svc.PutObjectRequest(putObjectInput)
-
-		// Presign the request with the specified expiration
-		putURLStr, err := req.Presign(putLifetime) // Use the PUT lifetime
+		attestationURLStr, err := store.PresignPut(fmt.Sprintf("phase2-%d.attestation.json", i), putLifetime)
 		if err != nil {
 			return err
 		}
 
 		fmt.Printf("%d: %s\n", i, putURLStr)
-
+		fmt.Printf("%d (attestation): %s\n", i, attestationURLStr)
 	}
 
 	return nil
@@ -332,6 +361,33 @@ func keys(cCtx *cli.Context) error {
 	}
 	r1cs.ReadFrom(r1csFile)
 
+	var beaconRecords []BeaconRecord
+
+	if phase1Round := cCtx.Uint64("phase1-beacon-round"); phase1Round > 0 {
+		fmt.Printf("Replaying phase 1 beacon round %d for the record\n", phase1Round)
+		_, record, err := fetchBeacon(cCtx, "phase1", phase1Round)
+		if err != nil {
+			return fmt.Errorf("phase 1 beacon not available: %w", err)
+		}
+		beaconRecords = append(beaconRecords, *record)
+	}
+
+	if phase2Round := cCtx.Uint64("phase2-beacon-round"); phase2Round > 0 {
+		fmt.Printf("Finalizing phase 2 with drand round %d\n", phase2Round)
+		beacon, record, err := fetchBeacon(cCtx, "phase2", phase2Round)
+		if err != nil {
+			return fmt.Errorf("phase 2 beacon not available: %w", err)
+		}
+		withBeaconRandomness(beacon, phase2.Contribute)
+		beaconRecords = append(beaconRecords, *record)
+	}
+
+	if len(beaconRecords) > 0 {
+		if err := writeBeaconRecords("beacon.json", beaconRecords); err != nil {
+			return err
+		}
+	}
+
 	// get number of constraints
 	nbConstraints := r1cs.GetNbConstraints()
 	fmt.Println("extracting keys")
@@ -354,9 +410,71 @@ func keys(cCtx *cli.Context) error {
 	}
 	vk.WriteTo(vkFile)
 
+	if cCtx.Bool("emit-solidity") {
+		fmt.Println("exporting solidity verifier")
+		if err := exportSolidity(&vk, cCtx); err != nil {
+			return fmt.Errorf("export solidity verifier: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// emittedContractNamePattern finds the Solidity contract name gnark actually
+// emitted, so --contract-name can rename it without assuming it's always
+// literally "Verifier".
+var emittedContractNamePattern = regexp.MustCompile(`(?m)^contract\s+(\w+)\s*\{`)
+
+// exportSolidity renders vk as a Solidity verifier contract per the
+// --pragma, --hash-to-field, --contract-name, --gnark-commitment, and
+// --commitment-verifier flags shared by the `sol` command and
+// `key --emit-solidity`, and writes it to <contract-name>.sol.
+func exportSolidity(vk *groth16.VerifyingKey, cCtx *cli.Context) error {
+	if !cCtx.Bool("gnark-commitment") || !cCtx.Bool("commitment-verifier") {
+		vk.PublicAndCommitmentCommitted = nil
+		vk.CommitmentKeys = nil
+	}
+
+	opts := []solidity.ExportOption{solidity.WithPragmaVersion(cCtx.String("pragma"))}
+
+	switch hashToField := cCtx.String("hash-to-field"); hashToField {
+	case "expand_message_xmd":
+		// gnark's own RFC 9380 default: leave opts alone so output matches
+		// what ExportSolidity produces with no WithHashToFieldFunction set.
+	case "sha256":
+		opts = append(opts, solidity.WithHashToFieldFunction(sha256.New()))
+	case "keccak256":
+		opts = append(opts, solidity.WithHashToFieldFunction(sha3.NewLegacyKeccak256()))
+	default:
+		return fmt.Errorf("unsupported --hash-to-field %q: want sha256, keccak256, or expand_message_xmd", hashToField)
+	}
+
+	var buf bytes.Buffer
+	if err := vk.ExportSolidity(&buf, opts...); err != nil {
+		return err
+	}
+	source := buf.String()
+
+	contractName := cCtx.String("contract-name")
+	match := emittedContractNamePattern.FindStringSubmatchIndex(source)
+	if match == nil {
+		return errors.New("exportSolidity: could not find the emitted contract's name in ExportSolidity's output")
+	}
+	emittedName := source[match[2]:match[3]]
+	if emittedName != contractName {
+		source = source[:match[2]] + contractName + source[match[3]:]
+	}
+
+	solFile, err := os.Create(contractName + ".sol")
+	if err != nil {
+		return err
+	}
+	defer solFile.Close()
+
+	_, err = solFile.WriteString(source)
+	return err
+}
+
 func sol(cCtx *cli.Context) error {
 	// sanity check
 	if cCtx.Args().Len() != 1 {
@@ -371,13 +489,7 @@ func sol(cCtx *cli.Context) error {
 	}
 	vk.ReadFrom(vkFile)
 
-	solFile, err := os.Create("Groth16Verifier.sol")
-	if err != nil {
-		return err
-	}
-
-	err = vk.ExportSolidity(solFile, solidity.WithPragmaVersion("0.8.20"))
-	return err
+	return exportSolidity(vk, cCtx)
 }
 
 func ClonePhase1(phase1 *mpcsetup.Phase1) mpcsetup.Phase1 {
@@ -406,100 +518,3 @@ func ClonePhase2(phase2 *mpcsetup.Phase2) mpcsetup.Phase2 {
 
 	return r
 }
-
-func GetS3Service(region string, anonymous bool) (*s3.S3, error) {
-	// Create custom AWS configuration
-	config := &aws.Config{
-		Region: aws.String(region),
-	}
-
-	if anonymous {
-		config.Credentials = credentials.AnonymousCredentials
-	}
-
-	customEndpoint, exists := os.LookupEnv("CUSTOM_ENDPOINT")
-
-	if exists {
-		config.Endpoint = aws.String(customEndpoint)
-		config.S3ForcePathStyle = aws.Bool(true)
-	}
-
-	// Create a new AWS session with the custom config
-	sess, err := session.NewSession(config)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create S3 service client
-	svc := s3.New(sess)
-
-	return svc, nil
-}
-
-func Download(svc *s3.S3, objectKey string) (*string, error) {
-
-	filePath := "./trusted-setup/" + objectKey
-
-	// Create a new file for writing the S3 object contents to
-	file, err := os.Create(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	// Create the download input parameters
-	downloadInput := &s3.GetObjectInput{
-		Bucket: aws.String(BucketName),
-		Key:    aws.String(objectKey),
-	}
-
-	// Download the file from S3
-	result, err := svc.GetObject(downloadInput)
-	if err != nil {
-		return nil, err
-	}
-	defer result.Body.Close()
-
-	// Write the contents to the local file
-	_, err = io.Copy(file, result.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	return &filePath, nil
-}
-
-func Upload(filePath string, presignedURL string) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return err
-	}
-	fileSize := fileInfo.Size()
-
-	request, err := http.NewRequest(http.MethodPut, presignedURL, file)
-	if err != nil {
-		return err
-	}
-
-	request.Header.Set("Content-Type", "application/octet-stream")
-	request.ContentLength = fileSize
-
-	client := &http.Client{} // Use the default client or configure one if needed
-	response, err := client.Do(request)
-	if err != nil {
-		return err
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode != http.StatusOK {
-		return fmt.Errorf("Upload failed: Status Code: %d", response.StatusCode)
-	}
-
-	return nil
-}
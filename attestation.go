@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Attestation binds a single phase2 contribution to the human who produced
+// it: the identity they supplied, the hash chain values the contribution
+// itself carries, and (if they passed --sign-key) an ed25519 signature over
+// that transition. It is written as phase2-<i>.attestation.json alongside
+// the contribution so a third party can confirm who contributed what
+// randomness without trusting the coordinator or bucket operator's word.
+type Attestation struct {
+	Index       int       `json:"index"`
+	Name        string    `json:"name,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	GitHub      string    `json:"github,omitempty"`
+	PrevHash    string    `json:"prev_hash"`
+	NewHash     string    `json:"new_hash"`
+	BeaconRound *uint64   `json:"beacon_round,omitempty"` // drand round this contribution was finalized with, if any
+	PubKey      string    `json:"pubkey,omitempty"`       // hex-encoded ed25519 public key
+	Signature   string    `json:"signature,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// attestationSigningBytes is the canonical byte representation a contributor
+// signs: every identity and transition field in the attestation except the
+// signature itself (PubKey included, so the signature also commits to which
+// key is supposed to be signing). Covering the full tuple, not just
+// PrevHash/NewHash, is what makes the signature actually bind the
+// attestation's identity fields -- Name, Email, GitHub, BeaconRound,
+// Timestamp -- to the contribution; a signature over the hashes alone would
+// let any of those be altered after the fact without breaking verification.
+func attestationSigningBytes(att *Attestation) []byte {
+	beaconRound := "none"
+	if att.BeaconRound != nil {
+		beaconRound = strconv.FormatUint(*att.BeaconRound, 10)
+	}
+	return []byte(fmt.Sprintf("%d|%s|%s|%s|%s|%s|%s|%s|%d",
+		att.Index, att.Name, att.Email, att.GitHub, att.PrevHash, att.NewHash, beaconRound, att.PubKey, att.Timestamp.Unix()))
+}
+
+// signAttestation fills in PubKey and Signature using the ed25519 key at
+// signKeyPath, generating one on first use the same way the coordinator
+// does. A blank signKeyPath leaves the attestation unsigned.
+func signAttestation(att *Attestation, signKeyPath string) error {
+	if signKeyPath == "" {
+		return nil
+	}
+	signKey, err := loadOrCreateSignKey(signKeyPath)
+	if err != nil {
+		return fmt.Errorf("load contributor sign key: %w", err)
+	}
+	att.PubKey = hex.EncodeToString(signKey.Public().(ed25519.PublicKey))
+	att.Signature = hex.EncodeToString(ed25519.Sign(signKey, attestationSigningBytes(att)))
+	return nil
+}
+
+// VerifyTranscript checks that entries form an unbroken hash chain starting
+// at originHash (the pre-ceremony phase2's hash; pass "" to skip that check)
+// and that every signed entry's signature verifies under its own PubKey over
+// the full attestation tuple (attestationSigningBytes), not just its hashes.
+// entries must already be sorted by Index. This lets an external auditor
+// confirm who actually contributed what, in what order, without trusting the
+// coordinator's or bucket operator's word for any of it.
+//
+// Every signature in this ceremony, contributor attestations included, is
+// ed25519 rather than BLS: the coordinator's own transcript entries
+// (transcriptSigningBytes) are ed25519-signed, and splitting the trust model
+// across two signature schemes for no operational gain would make one harder
+// to verify correctly than the other.
+func VerifyTranscript(entries []Attestation, originHash string) error {
+	prevHash := originHash
+	for i, att := range entries {
+		if att.Index != i {
+			return fmt.Errorf("transcript: expected index %d, got %d", i, att.Index)
+		}
+		if prevHash != "" && att.PrevHash != prevHash {
+			return fmt.Errorf("transcript: entry %d breaks the hash chain: expected prev_hash %q, got %q", i, prevHash, att.PrevHash)
+		}
+		if att.Signature != "" {
+			pubKeyBytes, err := hex.DecodeString(att.PubKey)
+			if err != nil || len(pubKeyBytes) != ed25519.PublicKeySize {
+				return fmt.Errorf("transcript: entry %d: malformed pubkey", i)
+			}
+			sigBytes, err := hex.DecodeString(att.Signature)
+			if err != nil {
+				return fmt.Errorf("transcript: entry %d: malformed signature: %w", i, err)
+			}
+			if !ed25519.Verify(pubKeyBytes, attestationSigningBytes(&att), sigBytes) {
+				return fmt.Errorf("transcript: entry %d: signature verification failed", i)
+			}
+		}
+		prevHash = att.NewHash
+	}
+	return nil
+}
+
+// transcriptCmd (transcript) walks phase2-0.attestation.json through
+// phase2-<finalIndex>.attestation.json, and consolidates them into a single
+// sorted transcript.json plus a human-readable TRANSCRIPT.md: the artifact a
+// project publishes so anyone can independently confirm which humans
+// contributed what randomness in which order.
+func transcriptCmd(cCtx *cli.Context) error {
+	if cCtx.Args().Len() != 1 {
+		return errors.New("please provide the correct arguments")
+	}
+
+	n, err := strconv.Atoi(cCtx.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("invalid final index %q: %w", cCtx.Args().Get(0), err)
+	}
+
+	store, err := storageFromContext(cCtx)
+	if err != nil {
+		return err
+	}
+
+	var entries []Attestation
+	for i := 0; i <= n; i++ {
+		key := fmt.Sprintf("phase2-%d.attestation.json", i)
+
+		rc, err := store.Get(key)
+		if err != nil {
+			return fmt.Errorf("download %s: %w", key, err)
+		}
+		var att Attestation
+		decodeErr := json.NewDecoder(rc).Decode(&att)
+		rc.Close()
+		if decodeErr != nil {
+			return fmt.Errorf("parse %s: %w", key, decodeErr)
+		}
+		entries = append(entries, att)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Index < entries[j].Index })
+
+	if err := VerifyTranscript(entries, ""); err != nil {
+		return fmt.Errorf("refusing to publish an inconsistent transcript: %w", err)
+	}
+
+	transcriptFile, err := os.Create("transcript.json")
+	if err != nil {
+		return err
+	}
+	defer transcriptFile.Close()
+	enc := json.NewEncoder(transcriptFile)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(entries); err != nil {
+		return err
+	}
+
+	return writeTranscriptMarkdown(entries)
+}
+
+// writeTranscriptMarkdown renders entries as the human-readable counterpart
+// to transcript.json.
+func writeTranscriptMarkdown(entries []Attestation) error {
+	mdFile, err := os.Create("TRANSCRIPT.md")
+	if err != nil {
+		return err
+	}
+	defer mdFile.Close()
+
+	fmt.Fprintln(mdFile, "# Phase 2 Contribution Transcript")
+	fmt.Fprintln(mdFile)
+	fmt.Fprintln(mdFile, "| Index | Name | GitHub | Email | Prev Hash | New Hash | Beacon Round | Signed |")
+	fmt.Fprintln(mdFile, "|---|---|---|---|---|---|---|---|")
+	for _, att := range entries {
+		signed := "no"
+		if att.Signature != "" {
+			signed = "yes (" + att.PubKey + ")"
+		}
+		beaconRound := "-"
+		if att.BeaconRound != nil {
+			beaconRound = strconv.FormatUint(*att.BeaconRound, 10)
+		}
+		fmt.Fprintf(mdFile, "| %d | %s | %s | %s | `%s` | `%s` | %s | %s |\n",
+			att.Index, att.Name, att.GitHub, att.Email, att.PrevHash, att.NewHash, beaconRound, signed)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// BeaconRecord is the provenance of a single beacon-seeded finalization
+// contribution, so a verifier can independently refetch it, rederive the
+// same 32-byte value, and confirm it's what was actually used. Round,
+// ChainHash and Signature are drand-specific and empty for other sources;
+// Detail carries a short source-specific description (e.g. an eth2 slot or
+// execution block number) for those.
+type BeaconRecord struct {
+	Phase     string `json:"phase"`  // "phase1" or "phase2"
+	Source    string `json:"source"` // "drand", "eth2-prev-randao", "eth-execution-blockhash", or "multi(...)"
+	Round     uint64 `json:"round,omitempty"`
+	ChainHash string `json:"chain_hash,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// BeaconSource is anything that can produce a fresh, publicly verifiable
+// beacon value to deterministically seed a contribution. drandSource and
+// ethBeaconSource both implement it; MultiSource combines several so a
+// compromise of any one source doesn't fully control the resulting
+// randomness.
+type BeaconSource interface {
+	Beacon() ([]byte, *BeaconRecord, error)
+}
+
+// drandConfigFromContext builds a DrandConfig from the --drand-url,
+// --drand-chain-hash and --drand-group-pubkey flags shared by every command
+// that can apply a beacon finalization.
+func drandConfigFromContext(cCtx *cli.Context) DrandConfig {
+	return DrandConfig{
+		URL:       cCtx.String("drand-url"),
+		ChainHash: cCtx.String("drand-chain-hash"),
+		PublicKey: cCtx.String("drand-group-pubkey"),
+	}
+}
+
+// drandSource adapts a drand Client into a BeaconSource for a fixed round.
+type drandSource struct {
+	client *Client
+	round  uint64
+}
+
+func (s *drandSource) Beacon() ([]byte, *BeaconRecord, error) {
+	beacon, r, err := s.client.Beacon(s.round)
+	if err != nil {
+		return nil, nil, err
+	}
+	return beacon, &BeaconRecord{
+		Source:    "drand",
+		Round:     r.Round,
+		ChainHash: s.client.cfg.ChainHash,
+		Signature: r.Signature,
+	}, nil
+}
+
+// beaconSourceFromContext builds the BeaconSource selected by --beacon-source
+// ("drand", the default; "eth2"; or "multi", which combines drand and eth2).
+// round is only meaningful for drand; it's ignored by the eth2 source, which
+// always reads the chain's current finalized block.
+func beaconSourceFromContext(cCtx *cli.Context, round uint64) (BeaconSource, error) {
+	switch source := cCtx.String("beacon-source"); source {
+	case "", "drand":
+		client, err := NewClient(drandConfigFromContext(cCtx))
+		if err != nil {
+			return nil, err
+		}
+		return &drandSource{client: client, round: round}, nil
+	case "eth2":
+		return newEthBeaconSource(ethConfigFromContext(cCtx))
+	case "multi":
+		drandClient, err := NewClient(drandConfigFromContext(cCtx))
+		if err != nil {
+			return nil, err
+		}
+		ethSource, err := newEthBeaconSource(ethConfigFromContext(cCtx))
+		if err != nil {
+			return nil, err
+		}
+		return &MultiSource{Sources: []BeaconSource{&drandSource{client: drandClient, round: round}, ethSource}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported --beacon-source %q: want drand, eth2, or multi", source)
+	}
+}
+
+// fetchBeacon fetches and verifies a beacon value from the source configured
+// on cCtx (--beacon-source, defaulting to drand), returning the derived
+// 32-byte value alongside the record to persist for replay.
+func fetchBeacon(cCtx *cli.Context, phase string, round uint64) ([]byte, *BeaconRecord, error) {
+	source, err := beaconSourceFromContext(cCtx, round)
+	if err != nil {
+		return nil, nil, err
+	}
+	beacon, record, err := source.Beacon()
+	if err != nil {
+		return nil, nil, err
+	}
+	record.Phase = phase
+	return beacon, record, nil
+}
+
+// MultiSource combines several BeaconSources into one by hashing their
+// values together, so that controlling any single underlying source isn't
+// enough to control the resulting beacon value.
+type MultiSource struct {
+	Sources []BeaconSource
+}
+
+func (m *MultiSource) Beacon() ([]byte, *BeaconRecord, error) {
+	if len(m.Sources) == 0 {
+		return nil, nil, errors.New("drand: MultiSource has no sources configured")
+	}
+
+	h := sha256.New()
+	sub := make([]BeaconRecord, 0, len(m.Sources))
+	detail := ""
+	for i, s := range m.Sources {
+		value, record, err := s.Beacon()
+		if err != nil {
+			return nil, nil, fmt.Errorf("multi source %d: %w", i, err)
+		}
+		h.Write(value)
+		sub = append(sub, *record)
+		if i > 0 {
+			detail += "+"
+		}
+		detail += record.Source
+	}
+	combined := h.Sum(nil)
+
+	detailJSON, err := json.Marshal(sub)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return combined, &BeaconRecord{
+		Source: fmt.Sprintf("multi(%s)", detail),
+		Detail: string(detailJSON),
+	}, nil
+}
+
+// beaconStream deterministically expands seed into an arbitrary-length
+// keystream via counter-mode SHA256, so a contribution seeded from it is
+// fully reproducible by anyone who recomputes the same beacon value.
+type beaconStream struct {
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+func (s *beaconStream) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(s.buf) == 0 {
+			var ctr [8]byte
+			binary.BigEndian.PutUint64(ctr[:], s.counter)
+			s.counter++
+			block := sha256.Sum256(append(append([]byte{}, s.seed...), ctr[:]...))
+			s.buf = block[:]
+		}
+		copied := copy(p[n:], s.buf)
+		s.buf = s.buf[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
+// withBeaconRandomness runs fn with crypto/rand.Reader replaced by a stream
+// deterministically derived from beacon, then restores the original reader.
+// This is the same trick reference "beacon" finalizations for other
+// ceremonies (e.g. the Powers of Tau beacon step) use to make a contribution
+// publicly reproducible instead of drawing fresh entropy: gnark's
+// mpcsetup.Contribute has no seed parameter, so the only way to make its
+// randomness a deterministic function of the beacon is to substitute the
+// package-level randomness source for the duration of the call.
+func withBeaconRandomness(beacon []byte, fn func()) {
+	prev := crand.Reader
+	crand.Reader = &beaconStream{seed: beacon}
+	defer func() { crand.Reader = prev }()
+	fn()
+}
+
+// beaconRoundCmd (beacon-round) resolves --at (defaulting to now) to the
+// drand round number that will be signed at that time on the configured
+// chain, via Client.RandomnessAtTime. This lets an operator schedule a
+// --phase2-beacon-round ahead of time (e.g. "an hour from now") instead of
+// computing the round number by hand from the chain's genesis time and
+// period.
+func beaconRoundCmd(cCtx *cli.Context) error {
+	client, err := NewClient(drandConfigFromContext(cCtx))
+	if err != nil {
+		return err
+	}
+
+	at := time.Now()
+	if raw := cCtx.String("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return fmt.Errorf("invalid --at %q, want RFC3339: %w", raw, err)
+		}
+		at = parsed
+	}
+
+	round, err := client.RandomnessAtTime(at)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(round)
+	return nil
+}
+
+// writeBeaconRecords persists records as beacon.json alongside the extracted
+// pk/vk so a verifier can replay the finalization.
+func writeBeaconRecords(path string, records []BeaconRecord) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16/bn254/mpcsetup"
+	"github.com/urfave/cli/v2"
+)
+
+// presignedSlotLifetime is how long a contributor has to claim and upload
+// their assigned slot before the coordinator lets another contributor take it.
+const presignedSlotLifetime = 2 * time.Hour
+
+// queueSlot tracks a single numbered contribution slot handed out to a
+// contributor.
+type queueSlot struct {
+	Index      int       `json:"index"`
+	PubKey     string    `json:"pubkey"` // hex-encoded ed25519 public key
+	AssignedAt time.Time `json:"assigned_at"`
+	Accepted   bool      `json:"accepted"`
+}
+
+// transcriptEntry is one signed, append-only record of an accepted
+// contribution, as described in the ceremony transcript.
+type transcriptEntry struct {
+	Index             int       `json:"index"`
+	ContributorPubKey string    `json:"contributor_pubkey"`
+	PrevHash          string    `json:"prev_hash"`
+	NewHash           string    `json:"new_hash"`
+	Timestamp         time.Time `json:"timestamp"`
+	CoordinatorSig    string    `json:"coordinator_sig"`
+}
+
+// registerRequest is the body of POST /queue/register.
+type registerRequest struct {
+	PubKey string `json:"pubkey"`
+}
+
+// registerResponse tells the contributor which slot they were assigned and
+// how to fetch the previous contribution and upload the next one.
+type registerResponse struct {
+	Index          int    `json:"index"`
+	PreviousGetURL string `json:"previous_get_url"`
+	UploadPutURL   string `json:"upload_put_url"`
+}
+
+// submitRequest is the body of POST /queue/submit, sent once the contributor
+// has finished uploading to UploadPutURL.
+type submitRequest struct {
+	Index int `json:"index"`
+}
+
+// coordinator holds the ceremony state: the canonical phase2 origin, the
+// accepted contribution chain, and the set of in-flight queue slots. All
+// access is serialized through mu so concurrent contributor requests can't
+// race each other onto the same slot.
+type coordinator struct {
+	mu         sync.Mutex
+	store      Storage
+	signKey    ed25519.PrivateKey
+	origin     *mpcsetup.Phase2
+	nextIndex  int
+	slots      map[int]*queueSlot
+	transcript []transcriptEntry
+}
+
+func newCoordinator(store Storage, signKey ed25519.PrivateKey) (*coordinator, error) {
+	originPath, err := Download(store, "phase2")
+	if err != nil {
+		return nil, fmt.Errorf("download origin phase2: %w", err)
+	}
+	originFile, err := os.Open(*originPath)
+	if err != nil {
+		return nil, err
+	}
+	defer originFile.Close()
+
+	origin := &mpcsetup.Phase2{}
+	if _, err := origin.ReadFrom(originFile); err != nil {
+		return nil, fmt.Errorf("parse origin phase2: %w", err)
+	}
+
+	return &coordinator{
+		store:   store,
+		signKey: signKey,
+		origin:  origin,
+		slots:   make(map[int]*queueSlot),
+	}, nil
+}
+
+// pendingKey is the quarantine object a contributor uploads to: it is never
+// served as anyone's "previous contribution" until handleSubmit has verified
+// it and promoted it to canonicalKey. This keeps a rejected or garbage
+// upload from ever being handed to the next contributor as their base.
+func pendingKey(index int) string {
+	return fmt.Sprintf("phase2-%d.pending", index)
+}
+
+func canonicalKey(index int) string {
+	return fmt.Sprintf("phase2-%d", index)
+}
+
+// expiredSlot finds the lowest-indexed slot assigned more than
+// presignedSlotLifetime ago that was never accepted -- the one actually
+// blocking the chain from progressing, since handleSubmit refuses index i
+// until index i-1 is accepted -- and reports it so handleRegister can hand
+// it to a new contributor instead of leaving it wedged. Call with c.mu held.
+func (c *coordinator) expiredSlot() (int, bool) {
+	cutoff := time.Now().Add(-presignedSlotLifetime)
+	for i := 0; i < c.nextIndex; i++ {
+		slot, ok := c.slots[i]
+		if ok && !slot.Accepted && slot.AssignedAt.Before(cutoff) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// handleRegister assigns a free slot to a contributor and returns a
+// presigned GET for the previous contribution plus a presigned PUT for the
+// quarantine object they must fill. The contributor's upload is not trusted
+// until handleSubmit verifies and promotes it. A slot assigned longer than
+// presignedSlotLifetime ago and never accepted is reclaimed and handed to
+// this registrant instead of growing the queue, so a contributor who
+// registers and vanishes doesn't wedge the chain behind them forever.
+func (c *coordinator) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req registerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pubKey, err := hex.DecodeString(req.PubKey)
+	if err != nil || len(pubKey) != ed25519.PublicKeySize {
+		http.Error(w, "pubkey must be a hex-encoded ed25519 public key", http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index, reclaimed := c.expiredSlot()
+	if !reclaimed {
+		index = c.nextIndex
+		c.nextIndex++
+	}
+	c.slots[index] = &queueSlot{Index: index, PubKey: req.PubKey, AssignedAt: time.Now()}
+
+	prevKey := "phase2"
+	if index > 0 {
+		prevKey = canonicalKey(index - 1)
+	}
+
+	getURL, err := c.store.PresignGet(prevKey, presignedSlotLifetime)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	putURL, err := c.store.PresignPut(pendingKey(index), presignedSlotLifetime)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, registerResponse{Index: index, PreviousGetURL: getURL, UploadPutURL: putURL})
+}
+
+// handleSubmit downloads the contribution the caller just uploaded to its
+// quarantine key, verifies it against the last *accepted* contribution (the
+// origin, for index 0), and only on success promotes the quarantined bytes
+// to the canonical phase2-<index> key and appends a signed transcript entry.
+// A submission that fails verification is rejected, the slot stays open,
+// and the quarantined upload is never exposed as anyone's "previous
+// contribution" — so a rejected or garbage blob can never be served to the
+// next contributor, and no contributor can base their work on anything
+// other than the last accepted link in the chain.
+func (c *coordinator) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req submitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	slot, ok := c.slots[req.Index]
+	if !ok {
+		http.Error(w, "unknown slot", http.StatusNotFound)
+		return
+	}
+	if slot.Accepted {
+		http.Error(w, "slot already accepted", http.StatusConflict)
+		return
+	}
+
+	prev := c.origin
+	if req.Index > 0 {
+		prevSlot, ok := c.slots[req.Index-1]
+		if !ok || !prevSlot.Accepted {
+			http.Error(w, "previous slot has not been accepted yet", http.StatusConflict)
+			return
+		}
+		prevPath, err := Download(c.store, canonicalKey(req.Index-1))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("download previous contribution: %v", err), http.StatusInternalServerError)
+			return
+		}
+		prevFile, err := os.Open(*prevPath)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer prevFile.Close()
+		prev = &mpcsetup.Phase2{}
+		if _, err := prev.ReadFrom(prevFile); err != nil {
+			http.Error(w, fmt.Sprintf("parse previous contribution: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	pendingPath, err := Download(c.store, pendingKey(req.Index))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("download submitted contribution: %v", err), http.StatusBadGateway)
+		return
+	}
+	pendingFile, err := os.Open(*pendingPath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer pendingFile.Close()
+
+	next := &mpcsetup.Phase2{}
+	if _, err := next.ReadFrom(pendingFile); err != nil {
+		http.Error(w, fmt.Sprintf("parse submitted contribution: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := mpcsetup.VerifyPhase2(prev, next); err != nil {
+		http.Error(w, fmt.Sprintf("rejected: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if _, err := pendingFile.Seek(0, io.SeekStart); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pendingInfo, err := pendingFile.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := c.store.Put(canonicalKey(req.Index), pendingFile, pendingInfo.Size()); err != nil {
+		http.Error(w, fmt.Sprintf("promote verified contribution: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	entry := transcriptEntry{
+		Index:             req.Index,
+		ContributorPubKey: slot.PubKey,
+		PrevHash:          hex.EncodeToString(prev.Hash),
+		NewHash:           hex.EncodeToString(next.Hash),
+		Timestamp:         time.Now().UTC(),
+	}
+	entry.CoordinatorSig = hex.EncodeToString(ed25519.Sign(c.signKey, transcriptSigningBytes(entry)))
+
+	slot.Accepted = true
+	c.transcript = append(c.transcript, entry)
+
+	writeJSON(w, entry)
+}
+
+// transcriptSigningBytes is the canonical byte representation the
+// coordinator signs for a transcript entry.
+func transcriptSigningBytes(e transcriptEntry) []byte {
+	return []byte(fmt.Sprintf("%d|%s|%s|%s|%d", e.Index, e.ContributorPubKey, e.PrevHash, e.NewHash, e.Timestamp.Unix()))
+}
+
+func (c *coordinator) handleTranscript(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeJSON(w, c.transcript)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
+
+func loadOrCreateSignKey(path string) (ed25519.PrivateKey, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		key, err := hex.DecodeString(string(data))
+		if err != nil || len(key) != ed25519.PrivateKeySize {
+			return nil, errors.New("coordinator sign key file is malformed")
+		}
+		return ed25519.PrivateKey(key), nil
+	}
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(priv)), 0600); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Generated new coordinator signing key at %s\n", path)
+	return priv, nil
+}
+
+func coordinatorCmd(cCtx *cli.Context) error {
+	if cCtx.Args().Len() != 1 {
+		return errors.New("please provide the correct arguments")
+	}
+
+	addr := cCtx.String("addr")
+	if addr == "" {
+		addr = ":8080"
+	}
+	signKeyPath := cCtx.String("sign-key")
+	if signKeyPath == "" {
+		signKeyPath = "./coordinator.key"
+	}
+
+	signKey, err := loadOrCreateSignKey(signKeyPath)
+	if err != nil {
+		return fmt.Errorf("load coordinator signing key: %w", err)
+	}
+
+	store, err := storageFromContext(cCtx)
+	if err != nil {
+		return err
+	}
+
+	coord, err := newCoordinator(store, signKey)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/queue/register", coord.handleRegister)
+	mux.HandleFunc("/queue/submit", coord.handleSubmit)
+	mux.HandleFunc("/transcript", coord.handleTranscript)
+
+	fmt.Printf("Coordinator listening on %s\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16/bn254/mpcsetup"
+	"github.com/urfave/cli/v2"
+)
+
+// memStorage is an in-memory Storage backed by an httptest.Server, standing
+// in for a real presigned-URL backend (S3, GCS, ...) so this test can drive
+// the full networked participant <-> coordinator HTTP flow without any cloud
+// credentials.
+type memStorage struct {
+	mu     sync.Mutex
+	blobs  map[string][]byte
+	server *httptest.Server
+}
+
+func newMemStorage() *memStorage {
+	s := &memStorage{blobs: make(map[string][]byte)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blobs/", func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/blobs/")
+		switch r.Method {
+		case http.MethodGet:
+			s.mu.Lock()
+			data, ok := s.blobs[key]
+			s.mu.Unlock()
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.mu.Lock()
+			s.blobs[key] = data
+			s.mu.Unlock()
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *memStorage) Get(key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	data, ok := s.blobs[key]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("memStorage: no object %q", key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memStorage) Put(key string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.blobs[key] = data
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memStorage) PresignPut(key string, ttl time.Duration) (string, error) {
+	return s.server.URL + "/blobs/" + key, nil
+}
+
+func (s *memStorage) PresignGet(key string, ttl time.Duration) (string, error) {
+	return s.server.URL + "/blobs/" + key, nil
+}
+
+// participantContext builds a *cli.Context carrying flags and positional args
+// the way urfave/cli would when parsing a real command line, without going
+// through cli.App.Run, so joinCmd/contributeCmd/submitCmd can be exercised
+// directly against a real httptest coordinator.
+func participantContext(t *testing.T, flags map[string]string, args []string) *cli.Context {
+	t.Helper()
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for name, value := range flags {
+		set.String(name, value, "")
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatalf("parse args: %v", err)
+	}
+	return cli.NewContext(nil, set, nil)
+}
+
+// TestNetworkedParticipantFlow exercises participant join, contribute, and
+// submit as separate processes would, over real HTTP, against a coordinator
+// whose handlers run unmodified. It is the one test in this repo that proves
+// the queue/register -> queue/submit wiring (slot assignment, quarantine
+// upload, verification against the last accepted contribution, and the
+// signed transcript entry) actually works end-to-end, rather than only
+// in-process against gnark's Phase2 API directly.
+func TestNetworkedParticipantFlow(t *testing.T) {
+	workDir := t.TempDir()
+	prevWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(prevWd)
+
+	store := newMemStorage()
+	defer store.server.Close()
+
+	origin := &mpcsetup.Phase2{}
+	var originBuf bytes.Buffer
+	if _, err := origin.WriteTo(&originBuf); err != nil {
+		t.Fatalf("serialize origin phase2: %v", err)
+	}
+	store.blobs["phase2"] = originBuf.Bytes()
+
+	_, coordSignKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coord, err := newCoordinator(store, coordSignKey)
+	if err != nil {
+		t.Fatalf("newCoordinator: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/queue/register", coord.handleRegister)
+	mux.HandleFunc("/queue/submit", coord.handleSubmit)
+	mux.HandleFunc("/transcript", coord.handleTranscript)
+	coordServer := httptest.NewServer(mux)
+	defer coordServer.Close()
+
+	joinCtx := participantContext(t,
+		map[string]string{"sign-key": "./participant.key"},
+		[]string{coordServer.URL})
+	if err := joinCmd(joinCtx); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	contributeCtx := participantContext(t, nil,
+		[]string{"./trusted-setup/phase2-0.prev", "./trusted-setup/phase2-0.contributed"})
+	if err := contributeCmd(contributeCtx); err != nil {
+		t.Fatalf("contribute: %v", err)
+	}
+
+	submitCtx := participantContext(t, nil,
+		[]string{"./trusted-setup/phase2-0.slot.json", "./trusted-setup/phase2-0.contributed"})
+	if err := submitCmd(submitCtx); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	if _, err := os.Stat("./trusted-setup/phase2-0.receipt.json"); err != nil {
+		t.Fatalf("submit did not write a receipt: %v", err)
+	}
+
+	coord.mu.Lock()
+	defer coord.mu.Unlock()
+	if len(coord.transcript) != 1 {
+		t.Fatalf("expected 1 accepted transcript entry, got %d", len(coord.transcript))
+	}
+	if !coord.slots[0].Accepted {
+		t.Fatal("slot 0 was not marked accepted")
+	}
+	if _, ok := store.blobs[canonicalKey(0)]; !ok {
+		t.Fatal("accepted contribution was never promoted to its canonical key")
+	}
+}
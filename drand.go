@@ -0,0 +1,319 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// drandG1DST is the ciphersuite drand's unchained, G1-signature networks
+// (quicknet and friends) hash round messages under. These chains publish a
+// G2 group public key.
+const drandG1DST = "BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_"
+
+// drandG2DST is the ciphersuite drand's chained, G2-signature networks (the
+// original League of Entropy mainnet chain) hash round messages under.
+// These chains publish a G1 group public key.
+const drandG2DST = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_"
+
+const (
+	// DefaultDrandURL is the HTTP API of the League of Entropy's public
+	// drand relays.
+	DefaultDrandURL = "https://api.drand.sh"
+	// DefaultQuicknetChainHash identifies the League of Entropy's quicknet
+	// beacon (3s period, unchained, BLS12-381 G1 signatures). See
+	// https://drand.love/developer/http-api/#public-endpoints.
+	DefaultQuicknetChainHash = "52db9ba70e0cc0f6eaf7803dd07447a1f5477735fd3f661792ba94600c84e971"
+)
+
+// DrandConfig pins the chain a beacon round is fetched from and the group
+// public key its signatures are verified against. PublicKey has no default:
+// an operator must pin it themselves (e.g. from https://drand.love/developer/http-api/#info)
+// rather than trust whatever the endpoint hands back.
+type DrandConfig struct {
+	URL       string
+	ChainHash string // hex-encoded
+	PublicKey string // hex-encoded, compressed G2 point (unchained schemes) or G1 point (chained schemes)
+}
+
+// DrandRound is one signed round of a drand beacon, as returned by
+// GET /<chainHash>/public/<round>. PreviousSignature is only present on
+// chained schemes.
+type DrandRound struct {
+	Round             uint64 `json:"round"`
+	Randomness        string `json:"randomness"`
+	Signature         string `json:"signature"`
+	PreviousSignature string `json:"previous_signature,omitempty"`
+}
+
+// drandInfo is the subset of GET /<chainHash>/info this package cares about.
+type drandInfo struct {
+	Hash        string `json:"hash"`
+	PublicKey   string `json:"public_key"`
+	Period      int64  `json:"period"`
+	GenesisTime int64  `json:"genesis_time"`
+	SchemeID    string `json:"schemeID"`
+}
+
+// chained reports whether this scheme signs round messages as
+// H(previous_signature || round) rather than the unchained H(round). Every
+// scheme drand has shipped names chained variants "...-chained" and
+// unchained variants "...-unchained" (quicknet's RFC9380 scheme included).
+func (info *drandInfo) chained() bool {
+	return strings.Contains(info.SchemeID, "chained") && !strings.Contains(info.SchemeID, "unchained")
+}
+
+// Client fetches and verifies rounds from a single pinned drand chain. It is
+// built once per invocation and reused so the pinned chain hash, group
+// public key, and chain scheme are only fetched/parsed once.
+type Client struct {
+	cfg        DrandConfig
+	chainHash  []byte
+	httpClient *http.Client
+
+	// Exactly one of these is set, detected from the byte length of
+	// cfg.PublicKey: a compressed G2 point identifies an unchained,
+	// G1-signature scheme (e.g. quicknet); a compressed G1 point identifies
+	// a chained, G2-signature scheme (e.g. the original League of Entropy
+	// mainnet chain). See verifyRound.
+	publicKeyG1 *bls12381.G1Affine
+	publicKeyG2 *bls12381.G2Affine
+
+	info *drandInfo // lazily populated by fetchInfo
+}
+
+// NewClient parses and validates cfg, defaulting URL and ChainHash to the
+// League of Entropy's quicknet beacon. cfg.PublicKey must be set: it is the
+// pinned root of trust every fetched round is verified against.
+func NewClient(cfg DrandConfig) (*Client, error) {
+	if cfg.URL == "" {
+		cfg.URL = DefaultDrandURL
+	}
+	if cfg.ChainHash == "" {
+		cfg.ChainHash = DefaultQuicknetChainHash
+	}
+	if cfg.PublicKey == "" {
+		return nil, errors.New("drand: --drand-group-pubkey must be set to the pinned group public key of the chain; refusing to trust the HTTP endpoint blindly")
+	}
+
+	chainHash, err := hex.DecodeString(cfg.ChainHash)
+	if err != nil {
+		return nil, fmt.Errorf("drand: invalid --drand-chain-hash: %w", err)
+	}
+	pubKeyBytes, err := hex.DecodeString(cfg.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("drand: invalid --drand-group-pubkey: %w", err)
+	}
+
+	client := &Client{
+		cfg:        cfg,
+		chainHash:  chainHash,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	switch len(pubKeyBytes) {
+	case bls12381.SizeOfG1AffineCompressed:
+		var pubKey bls12381.G1Affine
+		if _, err := pubKey.SetBytes(pubKeyBytes); err != nil {
+			return nil, fmt.Errorf("drand: decode G1 group public key: %w", err)
+		}
+		client.publicKeyG1 = &pubKey
+	case bls12381.SizeOfG2AffineCompressed:
+		var pubKey bls12381.G2Affine
+		if _, err := pubKey.SetBytes(pubKeyBytes); err != nil {
+			return nil, fmt.Errorf("drand: decode G2 group public key: %w", err)
+		}
+		client.publicKeyG2 = &pubKey
+	default:
+		return nil, fmt.Errorf("drand: --drand-group-pubkey is %d bytes, want %d (G1, chained schemes) or %d (G2, unchained schemes)",
+			len(pubKeyBytes), bls12381.SizeOfG1AffineCompressed, bls12381.SizeOfG2AffineCompressed)
+	}
+	return client, nil
+}
+
+// fetchInfo retrieves the chain's /info document and checks it against the
+// pinned chain hash and public key, so a rogue --drand-url can't silently
+// swap out the chain being read from underneath the pinned identity.
+func (c *Client) fetchInfo() (*drandInfo, error) {
+	if c.info != nil {
+		return c.info, nil
+	}
+
+	url := fmt.Sprintf("%s/%s/info", strings.TrimRight(c.cfg.URL, "/"), hex.EncodeToString(c.chainHash))
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("drand: fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("drand: fetch %s: unexpected status %d", url, resp.StatusCode)
+	}
+
+	var info drandInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("drand: decode /info: %w", err)
+	}
+	if !strings.EqualFold(info.Hash, hex.EncodeToString(c.chainHash)) {
+		return nil, fmt.Errorf("drand: /info hash %q does not match pinned chain hash %q", info.Hash, hex.EncodeToString(c.chainHash))
+	}
+	if !strings.EqualFold(info.PublicKey, c.cfg.PublicKey) {
+		return nil, errors.New("drand: /info public_key does not match the pinned --drand-group-pubkey")
+	}
+
+	c.info = &info
+	return c.info, nil
+}
+
+// Round fetches and verifies round from the chain, returning an error if
+// the round is unsigned (not yet reached), malformed, or fails signature
+// verification against the pinned group public key.
+func (c *Client) Round(round uint64) (*DrandRound, error) {
+	url := fmt.Sprintf("%s/%s/public/%d", strings.TrimRight(c.cfg.URL, "/"), hex.EncodeToString(c.chainHash), round)
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("drand: fetch round %d: %w", round, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("drand: round %d is not available yet (status %d)", round, resp.StatusCode)
+	}
+
+	var r DrandRound
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("drand: decode round %d: %w", round, err)
+	}
+	if r.Round != round {
+		return nil, fmt.Errorf("drand: requested round %d, got round %d", round, r.Round)
+	}
+
+	info, err := c.fetchInfo()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.verifyRound(info, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// verifyRound checks the BLS12-381 signature of r under the pinned group
+// public key and that the published randomness is SHA256(signature). The
+// signed message is SHA256(round) on unchained schemes (e.g. quicknet) or
+// SHA256(previous_signature || round) on chained ones, per info.chained().
+// Which curve the signature itself lives in follows from which curve the
+// pinned public key is in (see NewClient): a G2 public key means G1
+// signatures, a G1 public key means G2 signatures.
+func (c *Client) verifyRound(info *drandInfo, r *DrandRound) error {
+	sigBytes, err := hex.DecodeString(r.Signature)
+	if err != nil {
+		return fmt.Errorf("drand: round %d: invalid signature hex: %w", r.Round, err)
+	}
+
+	var roundBytes [8]byte
+	binary.BigEndian.PutUint64(roundBytes[:], r.Round)
+
+	var msg [32]byte
+	if info.chained() {
+		if r.PreviousSignature == "" {
+			return fmt.Errorf("drand: round %d: chain %q is chained but round has no previous_signature", r.Round, info.Hash)
+		}
+		prevSigBytes, err := hex.DecodeString(r.PreviousSignature)
+		if err != nil {
+			return fmt.Errorf("drand: round %d: invalid previous_signature hex: %w", r.Round, err)
+		}
+		msg = sha256.Sum256(append(append([]byte{}, prevSigBytes...), roundBytes[:]...))
+	} else {
+		msg = sha256.Sum256(roundBytes[:])
+	}
+
+	var verified bool
+	switch {
+	case c.publicKeyG2 != nil:
+		// Unchained schemes (e.g. quicknet): G1 signature, G2 public key.
+		// e(sig, g2) == e(H(msg), pubKey)
+		var sig bls12381.G1Affine
+		if _, err := sig.SetBytes(sigBytes); err != nil {
+			return fmt.Errorf("drand: round %d: decode signature: %w", r.Round, err)
+		}
+		h, err := bls12381.HashToG1(msg[:], []byte(drandG1DST))
+		if err != nil {
+			return fmt.Errorf("drand: round %d: hash to curve: %w", r.Round, err)
+		}
+		_, _, _, g2GenAff := bls12381.Generators()
+		var negSig bls12381.G1Affine
+		negSig.Neg(&sig)
+		verified, err = bls12381.PairingCheck([]bls12381.G1Affine{negSig, h}, []bls12381.G2Affine{g2GenAff, *c.publicKeyG2})
+		if err != nil {
+			return fmt.Errorf("drand: round %d: pairing check: %w", r.Round, err)
+		}
+	case c.publicKeyG1 != nil:
+		// Chained schemes (e.g. the original League of Entropy mainnet
+		// chain): G2 signature, G1 public key.
+		// e(g1, sig) == e(pubKey, H(msg))
+		var sig bls12381.G2Affine
+		if _, err := sig.SetBytes(sigBytes); err != nil {
+			return fmt.Errorf("drand: round %d: decode signature: %w", r.Round, err)
+		}
+		h, err := bls12381.HashToG2(msg[:], []byte(drandG2DST))
+		if err != nil {
+			return fmt.Errorf("drand: round %d: hash to curve: %w", r.Round, err)
+		}
+		_, _, g1GenAff, _ := bls12381.Generators()
+		var negPubKey bls12381.G1Affine
+		negPubKey.Neg(c.publicKeyG1)
+		verified, err = bls12381.PairingCheck([]bls12381.G1Affine{g1GenAff, negPubKey}, []bls12381.G2Affine{sig, h})
+		if err != nil {
+			return fmt.Errorf("drand: round %d: pairing check: %w", r.Round, err)
+		}
+	default:
+		return errors.New("drand: client has no group public key configured")
+	}
+	if !verified {
+		return fmt.Errorf("drand: round %d: signature verification failed against the pinned group public key", r.Round)
+	}
+
+	expectedRandomness := sha256.Sum256(sigBytes)
+	if !strings.EqualFold(hex.EncodeToString(expectedRandomness[:]), r.Randomness) {
+		return fmt.Errorf("drand: round %d: randomness is not SHA256(signature)", r.Round)
+	}
+	return nil
+}
+
+// RandomnessAtTime returns the round number that will be (or was) signed at
+// t, derived from the chain's genesis_time and period the same way drand's
+// own clients do.
+func (c *Client) RandomnessAtTime(t time.Time) (uint64, error) {
+	info, err := c.fetchInfo()
+	if err != nil {
+		return 0, err
+	}
+	if info.Period <= 0 {
+		return 0, errors.New("drand: chain info reports a non-positive period")
+	}
+	if t.Unix() <= info.GenesisTime {
+		return 1, nil
+	}
+	return uint64((t.Unix()-info.GenesisTime)/info.Period) + 1, nil
+}
+
+// Beacon fetches and verifies round, then derives the 32-byte value a
+// contribution is seeded with: SHA256(signature).
+func (c *Client) Beacon(round uint64) ([]byte, *DrandRound, error) {
+	r, err := c.Round(round)
+	if err != nil {
+		return nil, nil, err
+	}
+	sigBytes, err := hex.DecodeString(r.Signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	beacon := sha256.Sum256(sigBytes)
+	return beacon[:], r, nil
+}
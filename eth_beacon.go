@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// There is no default beacon-node or execution endpoint: unlike drand's
+// quicknet, there is no single canonical endpoint to trust out of the box,
+// so both must be configured explicitly.
+
+// EthConfig points at the beacon-node and execution-layer JSON-RPC endpoints
+// an ethBeaconSource reads from. At least one of Beacon2URL or ExecutionRPCURL
+// must be set. When Beacon2URL is set it is the only endpoint used;
+// ExecutionRPCURL is read only when Beacon2URL is empty, and a failed
+// Beacon2URL fetch is not retried against it.
+type EthConfig struct {
+	Beacon2URL      string
+	ExecutionRPCURL string
+}
+
+// ethConfigFromContext builds an EthConfig from the --eth2-url and
+// --eth-rpc-url flags.
+func ethConfigFromContext(cCtx *cli.Context) EthConfig {
+	return EthConfig{
+		Beacon2URL:      cCtx.String("eth2-url"),
+		ExecutionRPCURL: cCtx.String("eth-rpc-url"),
+	}
+}
+
+// ethBeaconSource is a BeaconSource backed by Ethereum's proof-of-stake
+// randomness: the finalized beacon block's prevRandao field, or (if no
+// beacon-node endpoint is configured) the finalized execution block's hash.
+type ethBeaconSource struct {
+	cfg        EthConfig
+	httpClient *http.Client
+}
+
+func newEthBeaconSource(cfg EthConfig) (*ethBeaconSource, error) {
+	if cfg.Beacon2URL == "" && cfg.ExecutionRPCURL == "" {
+		return nil, fmt.Errorf("eth beacon source: --eth2-url or --eth-rpc-url must be set")
+	}
+	return &ethBeaconSource{cfg: cfg, httpClient: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+func (s *ethBeaconSource) Beacon() ([]byte, *BeaconRecord, error) {
+	if s.cfg.Beacon2URL != "" {
+		return s.prevRandao()
+	}
+	return s.executionBlockHash()
+}
+
+// beacon2FinalizedBlockResponse is the subset of
+// GET /eth/v2/beacon/blocks/finalized this package cares about.
+type beacon2FinalizedBlockResponse struct {
+	Data struct {
+		Message struct {
+			Slot string `json:"slot"`
+			Body struct {
+				ExecutionPayload struct {
+					PrevRandao  string `json:"prev_randao"`
+					BlockNumber string `json:"block_number"`
+				} `json:"execution_payload"`
+			} `json:"body"`
+		} `json:"message"`
+	} `json:"data"`
+}
+
+// prevRandao fetches the latest finalized beacon block and returns its
+// prevRandao, the value RANDAO mixing commits to one epoch in advance and
+// which downstream execution-layer contracts can independently recompute
+// from the same finalized block.
+func (s *ethBeaconSource) prevRandao() ([]byte, *BeaconRecord, error) {
+	url := strings.TrimRight(s.cfg.Beacon2URL, "/") + "/eth/v2/beacon/blocks/finalized"
+	resp, err := s.httpClient.Get(url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("eth2: fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("eth2: fetch %s: unexpected status %d", url, resp.StatusCode)
+	}
+
+	var block beacon2FinalizedBlockResponse
+	if err := json.NewDecoder(resp.Body).Decode(&block); err != nil {
+		return nil, nil, fmt.Errorf("eth2: decode finalized block: %w", err)
+	}
+
+	randao, err := decodeHexPrefixed(block.Data.Message.Body.ExecutionPayload.PrevRandao)
+	if err != nil {
+		return nil, nil, fmt.Errorf("eth2: decode prev_randao: %w", err)
+	}
+
+	return randao, &BeaconRecord{
+		Source: "eth2-prev-randao",
+		Detail: fmt.Sprintf("slot %s, execution block %s", block.Data.Message.Slot, block.Data.Message.Body.ExecutionPayload.BlockNumber),
+	}, nil
+}
+
+// jsonRPCRequest and jsonRPCResponse are the minimal envelope needed to call
+// eth_getBlockByNumber against an execution-layer node.
+type jsonRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type jsonRPCResponse struct {
+	Result struct {
+		Number string `json:"number"`
+		Hash   string `json:"hash"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// executionBlockHash falls back to the execution layer's finalized block
+// hash when no beacon-node endpoint is configured. This is a weaker beacon
+// than prevRandao (a block producer has some influence over which
+// transactions land in the block, though not over the hash itself once
+// finalized), which is why it's only used as a fallback.
+func (s *ethBeaconSource) executionBlockHash() ([]byte, *BeaconRecord, error) {
+	reqBody, err := json.Marshal(jsonRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "eth_getBlockByNumber",
+		Params:  []interface{}{"finalized", false},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.httpClient.Post(s.cfg.ExecutionRPCURL, "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, nil, fmt.Errorf("eth rpc: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var rpcResp jsonRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return nil, nil, fmt.Errorf("eth rpc: decode response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return nil, nil, fmt.Errorf("eth rpc: %s", rpcResp.Error.Message)
+	}
+
+	hash, err := decodeHexPrefixed(rpcResp.Result.Hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("eth rpc: decode block hash: %w", err)
+	}
+
+	return hash, &BeaconRecord{
+		Source: "eth-execution-blockhash",
+		Detail: fmt.Sprintf("finalized execution block %s", rpcResp.Result.Number),
+	}, nil
+}
+
+// decodeHexPrefixed decodes a "0x"-prefixed hex string as used throughout
+// Ethereum's JSON APIs.
+func decodeHexPrefixed(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
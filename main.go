@@ -7,11 +7,81 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
+// solidityFlags configure the Solidity verifier exported from a verifying
+// key, whether from the standalone `sol` command or `key --emit-solidity`.
+var solidityFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "pragma",
+		Usage: "solidity pragma version for the generated verifier",
+		Value: "^0.8.20",
+	},
+	&cli.StringFlag{
+		Name:  "hash-to-field",
+		Usage: "hash-to-field function the verifier uses: sha256, keccak256, or expand_message_xmd (gnark's RFC 9380 default)",
+		Value: "expand_message_xmd",
+	},
+	&cli.StringFlag{
+		Name:  "contract-name",
+		Usage: "name of the generated Solidity contract",
+		Value: "Groth16Verifier",
+	},
+	&cli.BoolFlag{
+		Name:  "gnark-commitment",
+		Usage: "the circuit uses gnark's Pedersen commitment feature; set to false for circuits that don't use gnark commitments",
+		Value: true,
+	},
+	&cli.BoolFlag{
+		Name:  "commitment-verifier",
+		Usage: "include the on-chain Pedersen commitment check in the exported Solidity contract; set to false to omit it even when --gnark-commitment is true (e.g. to verify commitments off-chain instead)",
+		Value: true,
+	},
+}
+
+// drandFlags configure the drand chain a --beacon-round is fetched and
+// verified from; shared by every command that can apply a beacon
+// finalization.
+var drandFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "beacon-source",
+		Usage: "where a --beacon-round finalization draws its randomness from: drand, eth2, or multi (drand + eth2, combined)",
+		Value: "drand",
+	},
+	&cli.StringFlag{
+		Name:  "drand-url",
+		Usage: "drand HTTP relay to fetch beacon rounds from",
+		Value: DefaultDrandURL,
+	},
+	&cli.StringFlag{
+		Name:  "drand-chain-hash",
+		Usage: "hex-encoded chain hash of the drand beacon to pin to",
+		Value: DefaultQuicknetChainHash,
+	},
+	&cli.StringFlag{
+		Name:  "drand-group-pubkey",
+		Usage: "hex-encoded group public key to verify beacon signatures against (required to use --beacon-round with --beacon-source=drand or multi)",
+	},
+	&cli.StringFlag{
+		Name:  "eth2-url",
+		Usage: "beacon-node REST endpoint to read the finalized block's prevRandao from (required to use --beacon-source=eth2 or multi)",
+	},
+	&cli.StringFlag{
+		Name:  "eth-rpc-url",
+		Usage: "execution-layer JSON-RPC endpoint, used as a prevRandao fallback: reads the finalized block's hash instead",
+	},
+}
+
 func main() {
 	app := &cli.App{
 		Name:      "setup",
 		Usage:     "Use this tool to generate parameters of Groth16 via MPC",
 		UsageText: "setup command [arguments...]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "storage",
+				Usage: "storage backend for contributions: s3://<bucket>, gs://<bucket>, az://<account>/<container>, ipfs://<cidRoot>, file://<dir>",
+				Value: "s3://" + BucketName,
+			},
+		},
 		Commands: []*cli.Command{
 
 			/* ----------------------------- Phase 1 Import ----------------------------- */
@@ -27,12 +97,13 @@ func main() {
 				Usage:       "p2n <inputPh1> <inputR1cs> <outputPh2> <outputEvals>",
 				Description: "initialize phase 2 for the given circuit",
 				Action:      p2n,
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.Uint64Flag{
-						Name:  "beacon-round",
-						Usage: "drand round number for the phase1 beacon (env DRAND_PHASE1_ROUND)",
+						Name:    "beacon-round",
+						Usage:   "drand round number to finalize phase 1 with before initializing phase 2",
+						EnvVars: []string{"DRAND_PHASE1_ROUND"},
 					},
-				},
+				}, drandFlags...),
 			},
 			/* ----------------------------- Phase 2 Upload to S3 ----------------------- */
 			{
@@ -44,9 +115,31 @@ func main() {
 			/* --------------------------- Phase 2 Contribute --------------------------- */
 			{
 				Name:        "p2c",
-				Usage:       "p2c <uploadPresignedUrl> <bucketName>",
+				Usage:       "p2c <uploadPresignedUrl> <attestationUploadPresignedUrl>",
 				Description: "contribute phase 2 randomness for Groth16",
 				Action:      p2c,
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "contributor name to record in the attestation",
+					},
+					&cli.StringFlag{
+						Name:  "email",
+						Usage: "contributor email to record in the attestation",
+					},
+					&cli.StringFlag{
+						Name:  "github",
+						Usage: "contributor GitHub handle to record in the attestation",
+					},
+					&cli.StringFlag{
+						Name:  "sign-key",
+						Usage: "path to an ed25519 key file to sign the attestation with (generated on first use if absent); leave unset to skip signing",
+					},
+					&cli.Uint64Flag{
+						Name:  "beacon-round",
+						Usage: "drand round number to finalize this contribution with instead of fresh entropy, recorded to the attestation for audit",
+					},
+				}, drandFlags...),
 			},
 			/* ----------------------------- Phase 2 Verify ----------------------------- */
 			{
@@ -55,6 +148,33 @@ func main() {
 				Description: "verify phase 2 contributions for Groth16",
 				Action:      p2v,
 			},
+			/* ------------------------- Phase 2 Verify All (audit) ---------------------- */
+			{
+				Name:        "p2va",
+				Usage:       "p2va <bucketName> <finalIndex>",
+				Description: "verify every phase 2 contribution from the origin through phase2-<finalIndex> and report the transcript hash chain; contributions are read from the --storage backend, and the <bucketName> argument is not read",
+				Action:      p2va,
+			},
+			/* ------------------------------- Transcript -------------------------------- */
+			{
+				Name:        "transcript",
+				Usage:       "transcript <finalIndex>",
+				Description: "consolidate the signed per-contribution attestations into transcript.json and TRANSCRIPT.md",
+				Action:      transcriptCmd,
+			},
+			/* ------------------------------- Beacon Round ------------------------------ */
+			{
+				Name:        "beacon-round",
+				Usage:       "beacon-round",
+				Description: "resolve --at (default now) to the drand round number that will be signed at that time, for scheduling a --*-beacon-round ahead of time",
+				Action:      beaconRoundCmd,
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:  "at",
+						Usage: "RFC3339 timestamp to resolve to a round number; defaults to now",
+					},
+				}, drandFlags...),
+			},
 			/* ----------------------------- Generate presigned URLs -------------------- */
 			{
 				Name:        "presigned",
@@ -68,22 +188,80 @@ func main() {
 				Usage:       "key <phase1Path> <phase2Path> <phase2EvalsPath> <r1csPath>",
 				Description: "extract proving and verifying keys",
 				Action:      keys,
-				Flags: []cli.Flag{
+				Flags: append(append([]cli.Flag{
 					&cli.Uint64Flag{
-						Name:  "phase1-beacon-round",
-						Usage: "drand round number for the phase1 beacon (env DRAND_PHASE1_ROUND)",
+						Name:    "phase1-beacon-round",
+						Usage:   "drand round number the phase1 input was finalized with, recorded to beacon.json for audit",
+						EnvVars: []string{"DRAND_PHASE1_ROUND"},
 					},
 					&cli.Uint64Flag{
-						Name:  "phase2-beacon-round",
-						Usage: "drand round number for the phase2 beacon (env DRAND_PHASE2_ROUND)",
+						Name:    "phase2-beacon-round",
+						Usage:   "drand round number to finalize phase 2 with before extracting keys",
+						EnvVars: []string{"DRAND_PHASE2_ROUND"},
 					},
-				},
+					&cli.BoolFlag{
+						Name:  "emit-solidity",
+						Usage: "also write <contract-name>.sol exported from the extracted vk",
+					},
+				}, drandFlags...), solidityFlags...),
 			},
 			{
 				Name:        "sol",
 				Usage:       "sol <verifyingKey>",
 				Description: "export verifier smart contract from verifying key",
 				Action:      sol,
+				Flags:       solidityFlags,
+			},
+			/* ------------------------------- Participant ------------------------------ */
+			{
+				Name:  "participant",
+				Usage: "join, contribute to, and submit a slot in a networked coordinator ceremony",
+				Subcommands: []*cli.Command{
+					{
+						Name:        "join",
+						Usage:       "join <coordinatorAddr>",
+						Description: "register with a coordinator, reserving a slot and downloading the contribution to build on",
+						Action:      joinCmd,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "sign-key",
+								Usage: "path to this participant's ed25519 key file (generated on first use if absent)",
+								Value: "./participant.key",
+							},
+						},
+					},
+					{
+						Name:        "contribute",
+						Usage:       "contribute <inputPh2> <outputPh2>",
+						Description: "mix fresh randomness into a downloaded contribution; safe to run air-gapped",
+						Action:      contributeCmd,
+					},
+					{
+						Name:        "submit",
+						Usage:       "submit <slotFile> <contributedPh2>",
+						Description: "upload a contribution to the reserved slot and notify the coordinator for verification",
+						Action:      submitCmd,
+					},
+				},
+			},
+			/* ------------------------------- Coordinator ------------------------------ */
+			{
+				Name:        "coordinator",
+				Usage:       "coordinator <bucketName>",
+				Description: "run a long-lived ceremony coordinator that assigns slots, verifies contributions, and publishes a signed transcript; contributions are stored in the --storage backend, and the <bucketName> argument is not read",
+				Action:      coordinatorCmd,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "addr",
+						Usage: "address for the coordinator HTTP server to listen on",
+						Value: ":8080",
+					},
+					&cli.StringFlag{
+						Name:  "sign-key",
+						Usage: "path to the coordinator's ed25519 signing key (generated on first run if absent)",
+						Value: "./coordinator.key",
+					},
+				},
 			},
 		},
 	}
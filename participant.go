@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/consensys/gnark/backend/groth16/bn254/mpcsetup"
+	"github.com/urfave/cli/v2"
+)
+
+// slotFile is written by `participant join` and consumed by `participant
+// submit`. It carries everything a contributor needs to upload their
+// contribution and notify the coordinator without re-registering, so the
+// contribute step in between can run fully offline.
+type slotFile struct {
+	CoordinatorAddr string `json:"coordinator_addr"`
+	Index           int    `json:"index"`
+	UploadPutURL    string `json:"upload_put_url"`
+}
+
+// joinCmd (participant join) registers with a running coordinator, reserving
+// the next open slot, and downloads the contribution it must build on. It
+// writes phase2-<index>.prev (the input to `participant contribute`) and
+// phase2-<index>.slot.json (the input to `participant submit`).
+func joinCmd(cCtx *cli.Context) error {
+	if cCtx.Args().Len() != 1 {
+		return errors.New("please provide the correct arguments")
+	}
+	coordinatorAddr := cCtx.Args().Get(0)
+
+	signKeyPath := cCtx.String("sign-key")
+	if signKeyPath == "" {
+		signKeyPath = "./participant.key"
+	}
+	signKey, err := loadOrCreateSignKey(signKeyPath)
+	if err != nil {
+		return fmt.Errorf("load participant signing key: %w", err)
+	}
+	pubKey := hex.EncodeToString(signKey.Public().(ed25519.PublicKey))
+
+	reqBody, err := json.Marshal(registerRequest{PubKey: pubKey})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(coordinatorAddr+"/queue/register", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("register with coordinator: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("coordinator rejected registration: %s", body)
+	}
+	var reg registerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reg); err != nil {
+		return fmt.Errorf("decode registration response: %w", err)
+	}
+
+	fmt.Printf("Assigned slot %d, downloading previous contribution\n", reg.Index)
+	prevPath := fmt.Sprintf("./trusted-setup/phase2-%d.prev", reg.Index)
+	if err := downloadURL(reg.PreviousGetURL, prevPath); err != nil {
+		return fmt.Errorf("download previous contribution: %w", err)
+	}
+
+	slot := slotFile{CoordinatorAddr: coordinatorAddr, Index: reg.Index, UploadPutURL: reg.UploadPutURL}
+	slotPath := fmt.Sprintf("./trusted-setup/phase2-%d.slot.json", reg.Index)
+	slotFileHandle, err := os.Create(slotPath)
+	if err != nil {
+		return err
+	}
+	defer slotFileHandle.Close()
+	enc := json.NewEncoder(slotFileHandle)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(slot); err != nil {
+		return err
+	}
+
+	fmt.Printf("Downloaded %s\nRun `contribute %s <output>` to contribute offline, then `submit %s <output>`\n", prevPath, prevPath, slotPath)
+	return nil
+}
+
+// contributeCmd (participant contribute) reads the contribution downloaded by
+// `participant join`, mixes in fresh randomness, and writes the result. It
+// touches the network nowhere, so it's safe to run air-gapped between `join`
+// and `submit`.
+func contributeCmd(cCtx *cli.Context) error {
+	if cCtx.Args().Len() != 2 {
+		return errors.New("please provide the correct arguments")
+	}
+	inputPath := cCtx.Args().Get(0)
+	outputPath := cCtx.Args().Get(1)
+
+	inputFile, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	phase2 := &mpcsetup.Phase2{}
+	_, err = phase2.ReadFrom(inputFile)
+	inputFile.Close()
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", inputPath, err)
+	}
+
+	fmt.Printf("Generating contribution\n")
+	phase2.Contribute()
+
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer outputFile.Close()
+	_, err = phase2.WriteTo(outputFile)
+	return err
+}
+
+// submitCmd (participant submit) uploads a contribution produced by
+// `participant contribute` to the slot reserved by `participant join`, then
+// notifies the coordinator so it can verify and accept it. The coordinator's
+// response is the contributor's signed receipt that their contribution was
+// accepted into the transcript.
+func submitCmd(cCtx *cli.Context) error {
+	if cCtx.Args().Len() != 2 {
+		return errors.New("please provide the correct arguments")
+	}
+	slotPath := cCtx.Args().Get(0)
+	contributedPath := cCtx.Args().Get(1)
+
+	slotFileHandle, err := os.Open(slotPath)
+	if err != nil {
+		return err
+	}
+	var slot slotFile
+	decodeErr := json.NewDecoder(slotFileHandle).Decode(&slot)
+	slotFileHandle.Close()
+	if decodeErr != nil {
+		return fmt.Errorf("parse %s: %w", slotPath, decodeErr)
+	}
+
+	fmt.Printf("Uploading contribution for slot %d\n", slot.Index)
+	if err := Upload(contributedPath, slot.UploadPutURL); err != nil {
+		return fmt.Errorf("upload contribution: %w", err)
+	}
+
+	reqBody, err := json.Marshal(submitRequest{Index: slot.Index})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(slot.CoordinatorAddr+"/queue/submit", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("submit to coordinator: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("coordinator rejected contribution: %s", body)
+	}
+
+	var receipt transcriptEntry
+	if err := json.NewDecoder(resp.Body).Decode(&receipt); err != nil {
+		return fmt.Errorf("decode receipt: %w", err)
+	}
+
+	receiptPath := fmt.Sprintf("./trusted-setup/phase2-%d.receipt.json", slot.Index)
+	receiptFile, err := os.Create(receiptPath)
+	if err != nil {
+		return err
+	}
+	defer receiptFile.Close()
+	enc := json.NewEncoder(receiptFile)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(receipt); err != nil {
+		return err
+	}
+
+	fmt.Printf("Accepted: wrote signed receipt to %s\n", receiptPath)
+	return nil
+}
+
+// downloadURL saves the contents of a plain HTTP(S) URL to path, creating
+// the ./trusted-setup directory if it does not exist (other parent
+// directories of path are not created). Unlike Download, it fetches a bare URL
+// (e.g. a coordinator's presigned GET) rather than going through a Storage
+// backend.
+func downloadURL(url, path string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll("./trusted-setup", 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, resp.Body)
+	return err
+}
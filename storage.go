@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	azblobservice "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"cloud.google.com/go/storage"
+	"github.com/urfave/cli/v2"
+)
+
+// Storage is the backend every subcommand reads contributions from and
+// writes contributions to. It is deliberately narrow: a ceremony only ever
+// needs to fetch an object, write an object, or hand a contributor a
+// presigned URL to do one of those two things itself.
+type Storage interface {
+	// Get opens the object stored under key for reading.
+	Get(key string) (io.ReadCloser, error)
+	// Put uploads size bytes read from r to key.
+	Put(key string, r io.Reader, size int64) error
+	// PresignPut returns a URL a contributor without backend credentials can
+	// PUT their contribution to.
+	PresignPut(key string, ttl time.Duration) (string, error)
+	// PresignGet returns a URL a contributor without backend credentials can
+	// GET a contribution from.
+	PresignGet(key string, ttl time.Duration) (string, error)
+}
+
+// NewStorage parses a --storage URI and returns the matching backend.
+// Supported schemes: s3://<bucket>, gs://<bucket>, az://<account>/<container>,
+// ipfs://<cidRoot>, file://<dir>.
+func NewStorage(rawURL string) (Storage, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse --storage %q: %w", rawURL, err)
+	}
+
+	switch u.Scheme {
+	case "s3", "":
+		bucket := u.Host
+		if bucket == "" {
+			bucket = BucketName
+		}
+		return NewS3Storage(bucket)
+	case "gs":
+		return NewGCSStorage(u.Host)
+	case "az":
+		parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+		if u.Host == "" || len(parts) != 1 || parts[0] == "" {
+			return nil, fmt.Errorf("az:// storage URI must look like az://<account>/<container>, got %q", rawURL)
+		}
+		return NewAzureStorage(u.Host, parts[0])
+	case "ipfs":
+		return NewIPFSStorage(u.Host), nil
+	case "file":
+		dir := u.Path
+		if dir == "" {
+			dir = u.Host
+		}
+		return NewFileStorage(dir)
+	default:
+		return nil, fmt.Errorf("unsupported --storage scheme %q", u.Scheme)
+	}
+}
+
+// storageFromContext builds the Storage backend selected by the top-level
+// --storage flag, defaulting to the legacy AWS S3 bucket when unset.
+func storageFromContext(cCtx *cli.Context) (Storage, error) {
+	uri := cCtx.String("storage")
+	if uri == "" {
+		uri = "s3://" + BucketName
+	}
+	return NewStorage(uri)
+}
+
+/* --------------------------------- AWS S3 --------------------------------- */
+
+// s3Storage splits reads from writes across two clients: anonymousSvc for
+// Get, since ceremony artifacts are public and downloads shouldn't require
+// credentials, and authSvc for everything that mutates the bucket or signs
+// a URL on the caller's behalf (Put, PresignPut, PresignGet).
+type s3Storage struct {
+	anonymousSvc *s3.S3
+	authSvc      *s3.S3
+	bucket       string
+}
+
+func NewS3Storage(bucket string) (*s3Storage, error) {
+	anonymousSvc, err := GetS3Service(Region, true)
+	if err != nil {
+		return nil, err
+	}
+	authSvc, err := GetS3Service(Region, false)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Storage{anonymousSvc: anonymousSvc, authSvc: authSvc, bucket: bucket}, nil
+}
+
+func (s *s3Storage) Get(key string) (io.ReadCloser, error) {
+	out, err := s.anonymousSvc.GetObject(&s3.GetObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *s3Storage) Put(key string, r io.Reader, size int64) error {
+	body, ok := r.(io.ReadSeeker)
+	if !ok {
+		return errors.New("s3 storage requires a seekable reader")
+	}
+	_, err := s.authSvc.PutObject(&s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	})
+	return err
+}
+
+func (s *s3Storage) PresignPut(key string, ttl time.Duration) (string, error) {
+	req, _ := s.authSvc.PutObjectRequest(&s3.PutObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	return req.Presign(ttl)
+}
+
+func (s *s3Storage) PresignGet(key string, ttl time.Duration) (string, error) {
+	req, _ := s.authSvc.GetObjectRequest(&s3.GetObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	return req.Presign(ttl)
+}
+
+// GetS3Service builds an S3 client, honoring the CUSTOM_ENDPOINT env var used
+// to point at S3-compatible mirrors for offline or self-hosted setups.
+func GetS3Service(region string, anonymous bool) (*s3.S3, error) {
+	config := &aws.Config{
+		Region: aws.String(region),
+	}
+
+	if anonymous {
+		config.Credentials = credentials.AnonymousCredentials
+	}
+
+	if customEndpoint, exists := os.LookupEnv("CUSTOM_ENDPOINT"); exists {
+		config.Endpoint = aws.String(customEndpoint)
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.New(sess), nil
+}
+
+/* ----------------------------- Google Cloud Storage ----------------------------- */
+
+type gcsStorage struct {
+	client *storage.Client
+	bucket string
+}
+
+func NewGCSStorage(bucket string) (*gcsStorage, error) {
+	if bucket == "" {
+		return nil, errors.New("gs:// storage URI must include a bucket name")
+	}
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("create GCS client: %w", err)
+	}
+	return &gcsStorage{client: client, bucket: bucket}, nil
+}
+
+func (g *gcsStorage) Get(key string) (io.ReadCloser, error) {
+	return g.client.Bucket(g.bucket).Object(key).NewReader(context.Background())
+}
+
+func (g *gcsStorage) Put(key string, r io.Reader, size int64) error {
+	w := g.client.Bucket(g.bucket).Object(key).NewWriter(context.Background())
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// gcsSignedURLOptions builds the options SignedURL needs from the
+// GOOGLE_ACCESS_ID and GOOGLE_PRIVATE_KEY_PATH env vars, since a service
+// account's private key is required to sign a URL client-side.
+func gcsSignedURLOptions(method string, ttl time.Duration) (*storage.SignedURLOptions, error) {
+	accessID := os.Getenv("GOOGLE_ACCESS_ID")
+	keyPath := os.Getenv("GOOGLE_PRIVATE_KEY_PATH")
+	if accessID == "" || keyPath == "" {
+		return nil, errors.New("gs:// presigning requires GOOGLE_ACCESS_ID and GOOGLE_PRIVATE_KEY_PATH to be set")
+	}
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("read GOOGLE_PRIVATE_KEY_PATH: %w", err)
+	}
+	return &storage.SignedURLOptions{
+		GoogleAccessID: accessID,
+		PrivateKey:     key,
+		Method:         method,
+		Expires:        time.Now().Add(ttl),
+	}, nil
+}
+
+func (g *gcsStorage) PresignPut(key string, ttl time.Duration) (string, error) {
+	opts, err := gcsSignedURLOptions(http.MethodPut, ttl)
+	if err != nil {
+		return "", err
+	}
+	return g.client.Bucket(g.bucket).SignedURL(key, opts)
+}
+
+func (g *gcsStorage) PresignGet(key string, ttl time.Duration) (string, error) {
+	opts, err := gcsSignedURLOptions(http.MethodGet, ttl)
+	if err != nil {
+		return "", err
+	}
+	return g.client.Bucket(g.bucket).SignedURL(key, opts)
+}
+
+/* --------------------------------- Azure Blob --------------------------------- */
+
+type azureStorage struct {
+	service   *azblobservice.Client
+	container string
+}
+
+func NewAzureStorage(account, container string) (*azureStorage, error) {
+	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", account)
+
+	if connStr := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); connStr != "" {
+		client, err := azblobservice.NewClientFromConnectionString(connStr, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create Azure client from connection string: %w", err)
+		}
+		return &azureStorage{service: client, container: container}, nil
+	}
+
+	client, err := azblobservice.NewClientWithNoCredential(serviceURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create Azure client: %w", err)
+	}
+	return &azureStorage{service: client, container: container}, nil
+}
+
+func (a *azureStorage) blobClient(key string) *blob.Client {
+	return a.service.NewContainerClient(a.container).NewBlobClient(key)
+}
+
+func (a *azureStorage) Get(key string) (io.ReadCloser, error) {
+	resp, err := a.blobClient(key).DownloadStream(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (a *azureStorage) Put(key string, r io.Reader, size int64) error {
+	_, err := a.service.NewContainerClient(a.container).NewBlockBlobClient(key).UploadStream(context.Background(), r, nil)
+	return err
+}
+
+func (a *azureStorage) PresignPut(key string, ttl time.Duration) (string, error) {
+	perms := sas.BlobPermissions{Write: true, Create: true}
+	return a.blobClient(key).GetSASURL(perms, time.Now().Add(ttl), nil)
+}
+
+func (a *azureStorage) PresignGet(key string, ttl time.Duration) (string, error) {
+	perms := sas.BlobPermissions{Read: true}
+	return a.blobClient(key).GetSASURL(perms, time.Now().Add(ttl), nil)
+}
+
+/* ------------------------------ IPFS / HTTP mirror ------------------------------ */
+
+// DefaultIPFSGateways is tried in order until one serves the requested key.
+var DefaultIPFSGateways = []string{
+	"https://ipfs.io",
+	"https://cloudflare-ipfs.com",
+	"https://dweb.link",
+}
+
+// ipfsStorage fetches contributions by CID from a list of public gateways
+// over HTTP, trying each gateway in turn and returning the first successful
+// response; the fetched bytes are not checked against the CID. It is
+// read-only, since there is no single place to "upload" a pin to.
+type ipfsStorage struct {
+	cidRoot  string
+	gateways []string
+}
+
+func NewIPFSStorage(cidRoot string) *ipfsStorage {
+	return &ipfsStorage{cidRoot: cidRoot, gateways: DefaultIPFSGateways}
+}
+
+func (i *ipfsStorage) Get(key string) (io.ReadCloser, error) {
+	var lastErr error
+	for _, gateway := range i.gateways {
+		url := fmt.Sprintf("%s/ipfs/%s/%s", strings.TrimRight(gateway, "/"), i.cidRoot, key)
+		resp, err := http.Get(url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("%s: unexpected status %d", url, resp.StatusCode)
+			continue
+		}
+		return resp.Body, nil
+	}
+	return nil, fmt.Errorf("fetch %s from every configured IPFS gateway: %w", key, lastErr)
+}
+
+func (i *ipfsStorage) Put(key string, r io.Reader, size int64) error {
+	return errors.New("ipfs storage is read-only: pin the new contribution with your own node and re-run with an updated --storage CID")
+}
+
+func (i *ipfsStorage) PresignPut(key string, ttl time.Duration) (string, error) {
+	return "", errors.New("ipfs storage does not support presigned uploads")
+}
+
+func (i *ipfsStorage) PresignGet(key string, ttl time.Duration) (string, error) {
+	return fmt.Sprintf("%s/ipfs/%s/%s", strings.TrimRight(i.gateways[0], "/"), i.cidRoot, key), nil
+}
+
+/* ----------------------------- Local filesystem ----------------------------- */
+
+// fileStorage stores objects as plain files under root, for offline
+// air-gapped ceremonies with no network backend at all.
+type fileStorage struct {
+	root string
+}
+
+func NewFileStorage(root string) (*fileStorage, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &fileStorage{root: root}, nil
+}
+
+func (f *fileStorage) Get(key string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(f.root, key))
+}
+
+func (f *fileStorage) Put(key string, r io.Reader, size int64) error {
+	out, err := os.Create(filepath.Join(f.root, key))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, r)
+	return err
+}
+
+func (f *fileStorage) PresignPut(key string, ttl time.Duration) (string, error) {
+	return "file://" + filepath.Join(f.root, key), nil
+}
+
+func (f *fileStorage) PresignGet(key string, ttl time.Duration) (string, error) {
+	return "file://" + filepath.Join(f.root, key), nil
+}
+
+/* -------------------------------- Helpers -------------------------------- */
+
+// Download reads objectKey from store and stages it under ./trusted-setup/
+// the way every subcommand expects to find its inputs.
+func Download(store Storage, objectKey string) (*string, error) {
+	filePath := "./trusted-setup/" + objectKey
+
+	if err := os.MkdirAll("./trusted-setup", 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	body, err := store.Get(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	if _, err := io.Copy(file, body); err != nil {
+		return nil, err
+	}
+
+	return &filePath, nil
+}
+
+// Upload PUTs filePath to a presigned URL, e.g. the one a coordinator or
+// `presigned` handed out. This is backend-agnostic: the contributor never
+// needs credentials for the configured Storage, just the URL.
+func Upload(filePath string, presignedURL string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPut, presignedURL, file)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/octet-stream")
+	request.ContentLength = fileInfo.Size()
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("upload failed: status code %d", response.StatusCode)
+	}
+
+	return nil
+}
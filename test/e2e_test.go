@@ -1,9 +1,12 @@
 package test
 
 import (
+	"crypto/sha256"
+	"math/big"
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"unsafe"
 
@@ -29,6 +32,30 @@ type Config struct {
 	PkOutputPath                      string
 	VkOutputPath                      string
 	Power                             int
+	// BeaconSource finalizes phase2.Seal, mirroring how the main package's
+	// `key --phase2-beacon-round --beacon-source` plugs a drand/eth2/multi
+	// BeaconSource into the same step instead of hardcoding a challenge.
+	BeaconSource BeaconSource
+}
+
+// BeaconSource mirrors the main package's BeaconSource abstraction
+// (beacon.go): anything that can produce a fresh, publicly verifiable beacon
+// value to deterministically seed a finalization.
+type BeaconSource interface {
+	Beacon() ([]byte, error)
+}
+
+// fixedBeaconSource is a BeaconSource over a fixed, known seed: a stand-in
+// for a real drand/eth2 round in this offline test, so the test's challenge
+// construction mirrors the production path instead of special-casing a bare
+// []byte literal.
+type fixedBeaconSource struct {
+	seed []byte
+}
+
+func (f fixedBeaconSource) Beacon() ([]byte, error) {
+	sum := sha256.Sum256(f.seed)
+	return sum[:], nil
 }
 
 // getPhase1Commons extracts the unexported SrsCommons from Phase1 using reflection
@@ -49,6 +76,7 @@ func TestEndToEnd(t *testing.T) {
 		NContributionsPhase2:              3,
 		PkOutputPath:                      "../build/pk",
 		VkOutputPath:                      "../build/vk",
+		BeaconSource:                      fixedBeaconSource{seed: []byte("test-beacon-seed")},
 	}
 
 	r1csFile, err := os.Open(config.R1csPath)
@@ -128,8 +156,10 @@ func TestEndToEnd(t *testing.T) {
 		phase2File.Close()
 	}
 
-	// Use a deterministic beacon challenge for key extraction
-	beaconChallenge := []byte("test-beacon-challenge")
+	beaconChallenge, err := config.BeaconSource.Beacon()
+	if err != nil {
+		panic(err)
+	}
 
 	pk, vk := phase2.Seal(commons, &evals, beaconChallenge)
 
@@ -150,6 +180,24 @@ func TestEndToEnd(t *testing.T) {
 	vkTyped.WriteTo(vkFile)
 	vkFile.Close()
 
+	// Emit the Solidity verifier alongside pk/vk, as `key --emit-solidity` does,
+	// but with ExportSolidity's default options and gnark's own contract name.
+	var solBuf strings.Builder
+	if err := vkTyped.ExportSolidity(&solBuf); err != nil {
+		panic(err)
+	}
+	if !strings.Contains(solBuf.String(), "function verifyProof") {
+		t.Fatal("exported Solidity verifier is missing verifyProof")
+	}
+	solFile, err := os.Create("../build/Verifier.sol")
+	if err != nil {
+		panic(err)
+	}
+	if _, err := solFile.WriteString(solBuf.String()); err != nil {
+		panic(err)
+	}
+	solFile.Close()
+
 	// Build the witness
 	var preImage, hash fr.Element
 	{
@@ -178,4 +226,50 @@ func TestEndToEnd(t *testing.T) {
 	if err != nil {
 		panic(err)
 	}
+
+	calldata := solidityCalldata(proof.(*groth16Impl.Proof))
+
+	var gotAr curve.G1Affine
+	gotAr.X.SetBigInt(calldata[0])
+	gotAr.Y.SetBigInt(calldata[1])
+	if !gotAr.Equal(&proof.(*groth16Impl.Proof).Ar) {
+		t.Fatal("calldata[0:2] does not round-trip back to proof.Ar")
+	}
+
+	var gotBs curve.G2Affine
+	gotBs.X.A1.SetBigInt(calldata[2])
+	gotBs.X.A0.SetBigInt(calldata[3])
+	gotBs.Y.A1.SetBigInt(calldata[4])
+	gotBs.Y.A0.SetBigInt(calldata[5])
+	if !gotBs.Equal(&proof.(*groth16Impl.Proof).Bs) {
+		t.Fatal("calldata[2:6] does not round-trip back to proof.Bs")
+	}
+
+	var gotKrs curve.G1Affine
+	gotKrs.X.SetBigInt(calldata[6])
+	gotKrs.Y.SetBigInt(calldata[7])
+	if !gotKrs.Equal(&proof.(*groth16Impl.Proof).Krs) {
+		t.Fatal("calldata[6:8] does not round-trip back to proof.Krs")
+	}
+}
+
+// solidityCalldata lays out proof as the uint256[8] the exported Verifier.sol
+// expects for verifyProof(proof, input): A (G1: x, y), B (G2: x1, x0, y1, y0 —
+// EIP-197 order, which reverses the Fp2 coordinates), C (G1: x, y).
+func solidityCalldata(proof *groth16Impl.Proof) [8]*big.Int {
+	var calldata [8]*big.Int
+	for i := range calldata {
+		calldata[i] = new(big.Int)
+	}
+
+	proof.Ar.X.BigInt(calldata[0])
+	proof.Ar.Y.BigInt(calldata[1])
+	proof.Bs.X.A1.BigInt(calldata[2])
+	proof.Bs.X.A0.BigInt(calldata[3])
+	proof.Bs.Y.A1.BigInt(calldata[4])
+	proof.Bs.Y.A0.BigInt(calldata[5])
+	proof.Krs.X.BigInt(calldata[6])
+	proof.Krs.Y.BigInt(calldata[7])
+
+	return calldata
 }
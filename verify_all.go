@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/consensys/gnark/backend/groth16/bn254/mpcsetup"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// verifyStep is one entry of the p2va audit report: the outcome of
+// verifying a single phase2-i against phase2-(i-1) (or the origin, for i=0).
+type verifyStep struct {
+	Index               int    `json:"index"`
+	PrevHash            string `json:"prev_hash"`
+	ContributionHash    string `json:"contribution_hash"`
+	ContributorPubKeyG1 string `json:"contributor_pubkey_g1"`
+	TranscriptHash      string `json:"transcript_hash"`
+	Ok                  bool   `json:"ok"`
+	Error               string `json:"error,omitempty"`
+}
+
+// chainHash folds a contribution hash into the running transcript hash the
+// way gnark's mpcsetup chains it: BLAKE2b(prevTranscriptHash ||
+// contribution hash). Every contribution commits to this folded value in
+// its PublicKey.Hash, so p2va can assert chain-of-custody over the *entire*
+// transcript so far, not just the immediately preceding contribution the
+// way mpcsetup.VerifyPhase2 alone does.
+func chainHash(prevTranscriptHash, contributionHash []byte) []byte {
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		// blake2b.New256 only errors on a bad key, and we never pass one.
+		panic(err)
+	}
+	h.Write(prevTranscriptHash)
+	h.Write(contributionHash)
+	return h.Sum(nil)
+}
+
+// p2va (verify-all) walks the full phase2 contribution chain from the
+// origin through phase2-N, verifying every pairwise transition with
+// mpcsetup.VerifyPhase2 and asserting each contribution's PublicKey.Hash
+// commits to the correctly-folded transcript hash so far, so a third-party
+// auditor doesn't have to script the equivalent of what p2v does one
+// contribution at a time.
+func p2va(cCtx *cli.Context) error {
+	if cCtx.Args().Len() != 2 {
+		return errors.New("please provide the correct arguments")
+	}
+
+	n, err := strconv.Atoi(cCtx.Args().Get(1))
+	if err != nil {
+		return fmt.Errorf("invalid final index %q: %w", cCtx.Args().Get(1), err)
+	}
+
+	store, err := storageFromContext(cCtx)
+	if err != nil {
+		return err
+	}
+
+	originPath, err := Download(store, "phase2")
+	if err != nil {
+		return err
+	}
+	originFile, err := os.Open(*originPath)
+	if err != nil {
+		return err
+	}
+	origin := &mpcsetup.Phase2{}
+	origin.ReadFrom(originFile)
+	originFile.Close()
+
+	prev := origin
+	chain := chainHash(nil, origin.Hash)
+
+	var report []verifyStep
+	failed := false
+
+	for i := 0; i <= n; i++ {
+		step := verifyStep{Index: i, PrevHash: hex.EncodeToString(prev.Hash)}
+
+		contribKey := fmt.Sprintf("phase2-%d", i)
+		contribPath, err := Download(store, contribKey)
+		if err != nil {
+			step.Error = fmt.Sprintf("download %s: %v", contribKey, err)
+			report = append(report, step)
+			failed = true
+			break
+		}
+		contribFile, err := os.Open(*contribPath)
+		if err != nil {
+			step.Error = err.Error()
+			report = append(report, step)
+			failed = true
+			break
+		}
+		contrib := &mpcsetup.Phase2{}
+		contrib.ReadFrom(contribFile)
+		contribFile.Close()
+
+		step.ContributionHash = hex.EncodeToString(contrib.Hash)
+		step.ContributorPubKeyG1 = fmt.Sprintf("%x", contrib.PublicKey)
+
+		chain = chainHash(chain, contrib.Hash)
+		step.TranscriptHash = hex.EncodeToString(chain)
+
+		if err := mpcsetup.VerifyPhase2(prev, contrib); err != nil {
+			step.Error = err.Error()
+		} else if !bytes.Equal(chain, contrib.PublicKey.Hash) {
+			step.Error = fmt.Sprintf("transcript hash mismatch: folded %x, contribution's public key commits to %x", chain, contrib.PublicKey.Hash)
+		} else {
+			step.Ok = true
+		}
+
+		report = append(report, step)
+		if !step.Ok {
+			failed = true
+			break
+		}
+		prev = contrib
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+	return nil
+}